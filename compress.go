@@ -0,0 +1,113 @@
+package minify_render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// gzipCompress comprime data usando compress/gzip no nível informado (ver
+// resolveGzipLevel para como o nível é decidido a partir de RendererOptions).
+func gzipCompress(data []byte, level int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := gzip.NewWriterLevel(buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// brotliCompress comprime data usando github.com/andybalholm/brotli no nível
+// informado (ver resolveBrotliLevel para como o nível é decidido a partir de
+// RendererOptions).
+func brotliCompress(data []byte, level int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriterLevel(buf, level)
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resolveGzipLevel retorna *level quando o chamador definiu um valor, e
+// gzip.DefaultCompression caso contrário. Só o ponteiro nil é tratado como
+// "não definido" — ao contrário de um campo int simples, isso não conflita o
+// valor zero (gzip.NoCompression, um nível legítimo) com "usar o padrão".
+func resolveGzipLevel(level *int) int {
+	if level != nil {
+		return *level
+	}
+	return gzip.DefaultCompression
+}
+
+// resolveBrotliLevel é o equivalente de resolveGzipLevel para brotli.
+func resolveBrotliLevel(level *int) int {
+	if level != nil {
+		return *level
+	}
+	return brotli.DefaultCompression
+}
+
+// bestEncoding escolhe, dentre as variantes disponíveis em ci, a melhor
+// codificação aceita pelo cliente segundo o header Accept-Encoding,
+// preferindo brotli sobre gzip sobre identity.
+func bestEncoding(acceptEncoding string, ci CachedItem) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepts := func(name string) bool {
+		for _, part := range strings.Split(acceptEncoding, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			fields := strings.SplitN(part, ";", 2)
+			enc := strings.TrimSpace(fields[0])
+			if !strings.EqualFold(enc, name) {
+				continue
+			}
+			if len(fields) == 2 {
+				if q, ok := parseQValue(fields[1]); ok && q == 0 {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+
+	if len(ci.Brotli) > 0 && accepts("br") {
+		return "br"
+	}
+	if len(ci.Gzip) > 0 && accepts("gzip") {
+		return "gzip"
+	}
+	return ""
+}
+
+// parseQValue extrai o valor de "q=0.5" de um parâmetro do Accept-Encoding.
+func parseQValue(param string) (float64, bool) {
+	param = strings.TrimSpace(param)
+	if !strings.HasPrefix(param, "q=") {
+		return 0, false
+	}
+	q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+	if err != nil {
+		return 0, false
+	}
+	return q, true
+}
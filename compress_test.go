@@ -0,0 +1,68 @@
+package minify_render
+
+import (
+	"compress/gzip"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestBestEncoding(t *testing.T) {
+	both := CachedItem{Gzip: []byte("gz"), Brotli: []byte("br")}
+	gzipOnly := CachedItem{Gzip: []byte("gz")}
+
+	cases := []struct {
+		name           string
+		acceptEncoding string
+		ci             CachedItem
+		want           string
+	}{
+		{"no header", "", both, ""},
+		{"prefers brotli over gzip", "gzip, br", both, "br"},
+		{"falls back to gzip when brotli variant missing", "gzip, br", gzipOnly, "gzip"},
+		{"no variant available for what client accepts", "deflate", both, ""},
+		{"q=0 excludes brotli", "br;q=0, gzip", both, "gzip"},
+		{"q=0 on everything excludes all", "br;q=0, gzip;q=0", both, ""},
+		{"whitespace and casing are tolerated", " GZIP , BR;q=1 ", both, "br"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := bestEncoding(tc.acceptEncoding, tc.ci); got != tc.want {
+				t.Errorf("bestEncoding(%q) = %q, want %q", tc.acceptEncoding, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseQValue(t *testing.T) {
+	if q, ok := parseQValue("q=0.5"); !ok || q != 0.5 {
+		t.Errorf("parseQValue(q=0.5) = %v, %v, want 0.5, true", q, ok)
+	}
+	if _, ok := parseQValue("level=1"); ok {
+		t.Errorf("parseQValue(level=1) should not parse as a q-value")
+	}
+	if _, ok := parseQValue("q=not-a-number"); ok {
+		t.Errorf("parseQValue(q=not-a-number) should not parse")
+	}
+}
+
+func TestResolveGzipLevel(t *testing.T) {
+	if got := resolveGzipLevel(nil); got != gzip.DefaultCompression {
+		t.Errorf("resolveGzipLevel(nil) = %d, want gzip.DefaultCompression", got)
+	}
+	zero := 0
+	if got := resolveGzipLevel(&zero); got != 0 {
+		t.Errorf("resolveGzipLevel(&0) = %d, want 0 (explicit NoCompression, not the default)", got)
+	}
+}
+
+func TestResolveBrotliLevel(t *testing.T) {
+	if got := resolveBrotliLevel(nil); got != brotli.DefaultCompression {
+		t.Errorf("resolveBrotliLevel(nil) = %d, want brotli.DefaultCompression", got)
+	}
+	zero := 0
+	if got := resolveBrotliLevel(&zero); got != 0 {
+		t.Errorf("resolveBrotliLevel(&0) = %d, want 0", got)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -17,36 +21,27 @@ import (
 	"github.com/tdewolff/minify/v2"
 	mincss "github.com/tdewolff/minify/v2/css"
 	minhtml "github.com/tdewolff/minify/v2/html"
+	minjson "github.com/tdewolff/minify/v2/json"
+	minsvg "github.com/tdewolff/minify/v2/svg"
+	minxml "github.com/tdewolff/minify/v2/xml"
 )
 
+const defaultContentType = "text/html; charset=utf-8"
+
 var (
 	RENDERER *Renderer
 )
 
-// CachedItem guarda o corpo minificado e o etag
-type CachedItem struct {
-	Body        []byte
-	ETag        string
-	ContentType string
-	CreatedAt   time.Time
-}
-
-type Renderer struct {
-	// old field removed or kept for backward compat — we'll use atomic storage
-	// templates *template.Template   // NOT used directly anymore
-	templatesVal    atomic.Value // stores *template.Template
-	templatePattern string
-	funcMap         template.FuncMap
-	autoReload      bool
-
-	minifier        *minify.M
-	cache           *lru.Cache
-	templateVersion string
-	ttl             time.Duration
+// NewRendererWithFuncs mantém o comportamento original, sem compressão
+// pré-computada. Equivale a NewRendererWithOptions com RendererOptions{}.
+func NewRendererWithFuncs(pattern, version string, ttl time.Duration, cacheSize int, funcs template.FuncMap) (*Renderer, error) {
+	return NewRendererWithOptions(pattern, version, ttl, cacheSize, funcs, RendererOptions{})
 }
 
-// --- no NewRendererWithFuncs (apenas adicionar armazenamento da pattern/funcs e store inicial) ---
-func NewRendererWithFuncs(pattern, version string, ttl time.Duration, cacheSize int, funcs template.FuncMap) (*Renderer, error) {
+// NewRendererWithOptions é como NewRendererWithFuncs, mas permite habilitar o
+// cálculo e armazenamento em cache de variantes gzip/brotli do corpo
+// minificado, evitando recomprimir a cada requisição.
+func NewRendererWithOptions(pattern, version string, ttl time.Duration, cacheSize int, funcs template.FuncMap, opts RendererOptions) (*Renderer, error) {
 	// --- parse initial templates as before ---
 	root := template.New("").Funcs(funcs)
 	tmpl, err := root.ParseGlob(pattern)
@@ -56,6 +51,9 @@ func NewRendererWithFuncs(pattern, version string, ttl time.Duration, cacheSize
 
 	m := minify.New()
 	m.AddFunc("text/css", mincss.Minify)
+	m.AddFunc("application/json", minjson.Minify)
+	m.AddFunc("application/xml", minxml.Minify)
+	m.AddFunc("image/svg+xml", minsvg.Minify)
 	htmlMin := &minhtml.Minifier{
 		KeepSpecialComments: true,
 		KeepDocumentTags:        true,
@@ -64,31 +62,51 @@ func NewRendererWithFuncs(pattern, version string, ttl time.Duration, cacheSize
 	m.Add("text/html", htmlMin)
 
 
-	var c *lru.Cache
-	if cacheSize > 0 {
-		c, err = lru.New(cacheSize)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		c = nil
-	}
-
 	r := &Renderer{
 		templatePattern: pattern,
 		funcMap:         funcs,
 		autoReload:      false,
 		minifier:        m,
-		cache:           c,
 		templateVersion: version,
 		ttl:             ttl,
+		gzipEnabled:     opts.GzipEnabled,
+		gzipLevel:       resolveGzipLevel(opts.GzipLevel),
+		brotliEnabled:   opts.BrotliEnabled,
+		brotliLevel:     resolveBrotliLevel(opts.BrotliLevel),
+		tagIndex:        make(map[string]map[string]struct{}),
+		keyTags:         make(map[string]map[string]struct{}),
 	}
+
+	if cacheSize > 0 {
+		// NewWithEvict (not New) so onEvicted can drop a key from
+		// tagIndex/keyTags the moment capacity pressure evicts it — otherwise
+		// those maps would grow unbounded on keys the LRU itself already
+		// forgot about.
+		c, err := lru.NewWithEvict(cacheSize, r.onEvicted)
+		if err != nil {
+			return nil, err
+		}
+		r.cache = c
+	}
+
 	// store initial template atomically
 	r.templatesVal.Store(tmpl)
 
 	return r, nil
 }
 
+// onEvicted é o callback de evicção do LRU: remove key de tagIndex/keyTags
+// para que elas fiquem sempre consistentes com o que de fato está em cache,
+// mesmo quando a saída é por pressão de capacidade e não por ClearCache ou
+// InvalidateTag/InvalidateKey (que já cuidam disso por conta própria).
+func (r *Renderer) onEvicted(key, _ interface{}) {
+	k, ok := key.(string)
+	if !ok {
+		return
+	}
+	r.untagKey(k)
+}
+
 // ReloadTemplates reparseia os templates e substitui de forma atômica.
 // Chame ClearCache() após esta chamada para evitar servir HTML antigo.
 func (r *Renderer) ReloadTemplates() error {
@@ -98,6 +116,10 @@ func (r *Renderer) ReloadTemplates() error {
 		return err
 	}
 	r.templatesVal.Store(tmpl)
+	// bump the generation so any cache entry keyed under the old templates
+	// (e.g. written by a render that was already in flight) is never looked
+	// up again, even if it slips into the cache after the purge below
+	atomic.AddInt64(&r.templateGen, 1)
 	// evict cache to avoid serving stale pages
 	r.ClearCache()
 	return nil
@@ -111,7 +133,8 @@ func (r *Renderer) currentTemplate() *template.Template {
 	return v.(*template.Template)
 }
 
-// helper: checa se If-None-Match contains the ETag (handles multiple values)
+// inmMatches checa se o header If-None-Match contém etag, tratando múltiplos
+// valores separados por vírgula e as formas forte e fraca (W/"...").
 func inmMatches(inm string, etag string) bool {
 	if inm == "" {
 		return false
@@ -153,33 +176,34 @@ func (r *Renderer) RenderOnlyGet(status_http int, c *gin.Context, name string, d
 	r.Render(status_http, c, name, data)
 }
 
-func (r *Renderer) Render(status_http int,c *gin.Context, name string, data any) {
+func (r *Renderer) Render(status_http int, c *gin.Context, name string, data any) {
+	r.RenderWithOptions(status_http, c, name, data, RenderParams{})
+}
+
+// RenderWithOptions é como Render, mas aceita RenderParams para recursos
+// opcionais por-render, como CacheTags.
+func (r *Renderer) RenderWithOptions(status_http int, c *gin.Context, name string, data any, opts RenderParams) {
 	if IsDebugMode() {
 		if err := r.ReloadTemplates(); err != nil {
 			log.Printf("reload templates failed: %v", err)
 		}
 	}
 
-	contentType := "text/html; charset=utf-8"
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	mediaType := mediaTypeOf(contentType)
 
-	key := c.Request.URL.Path + "?" + c.Request.URL.RawQuery + "|tmpl:" + name + "|v:" + r.templateVersion
+	key := c.Request.URL.Path + "?" + c.Request.URL.RawQuery + "|tmpl:" + name + "|ct:" + mediaType +
+		"|v:" + r.templateVersion + "|g:" + strconv.FormatInt(atomic.LoadInt64(&r.templateGen), 10)
 
 	// If cache is enabled, attempt read path
 	if r.cache != nil {
 		if v, ok := r.cache.Get(key); ok {
 			ci := v.(CachedItem)
 			if time.Since(ci.CreatedAt) < r.ttl {
-				if inmMatches(c.GetHeader("If-None-Match"), ci.ETag) {
-					c.Status(http.StatusNotModified)
-					return
-				}
-				
-				c.Header("Content-Type", contentType)
-				c.Header("ETag", `W/"`+ci.ETag+`"`)
-				c.Header("Cache-Control", "public, max-age=60")
-				c.Header("Vary", "Accept-Encoding")
-				c.Writer.WriteHeader(status_http)
-				_, _ = c.Writer.Write(ci.Body)
+				r.serve(c, status_http, ci)
 				return
 			}
 			// expired -> evict
@@ -187,60 +211,215 @@ func (r *Renderer) Render(status_http int,c *gin.Context, name string, data any)
 		}
 	}
 
-	// render to buffer
+	// If cache is enabled, coalesce concurrent misses for the same key: only one
+	// goroutine does the execute+minify+compress work, the rest wait for and
+	// share the resulting CachedItem. The key carries templateVersion and the
+	// templateGen counter that ReloadTemplates bumps, so a mid-flight
+	// ReloadTemplates can't poison renders started afterwards.
+	if r.cache != nil {
+		v, err, _ := r.sf.Do(key, func() (interface{}, error) {
+			return r.renderAndCache(key, contentType, mediaType, name, data, opts.CacheTags)
+		})
+		if err != nil {
+			log.Printf("render error: %v", err)
+			c.String(http.StatusInternalServerError, "template render error")
+			return
+		}
+		r.serve(c, status_http, v.(CachedItem))
+		return
+	}
+
+	if err := r.renderDirect(status_http, c, name, data, contentType, mediaType); err != nil {
+		log.Printf("render error: %v", err)
+		c.String(http.StatusInternalServerError, "template render error")
+	}
+}
+
+// renderDirect executa e minifica name direto para c.Writer, sem passar pelo
+// cache. Usado quando o cache está desabilitado e por RenderError, cuja saída
+// nunca deve ser compartilhada entre erros/requisições distintas. Não
+// escreve nada em c.Writer até ter o corpo completo, para que o chamador
+// possa cair com segurança em outra resposta em caso de erro.
+func (r *Renderer) renderDirect(status_http int, c *gin.Context, name string, data any, contentType, mediaType string) error {
+	tmpl := r.currentTemplate()
+	if tmpl == nil {
+		return errors.New("no template loaded")
+	}
+
 	buf := &bytes.Buffer{}
-	
-    tmpl := r.currentTemplate()
+	if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+		return fmt.Errorf("template execute error: %w", err)
+	}
+
+	dst := &bytes.Buffer{}
+	if err := r.minifier.Minify(mediaType, dst, bytes.NewReader(buf.Bytes())); err != nil {
+		log.Printf("minify error (fallback): %v", err)
+		dst.Reset()
+		_, _ = io.Copy(dst, bytes.NewReader(buf.Bytes()))
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Writer.WriteHeader(status_http)
+	_, _ = io.Copy(c.Writer, bytes.NewReader(dst.Bytes()))
+	return nil
+}
+
+// mediaTypeOf remove os parâmetros (ex.: "; charset=utf-8") de um valor de
+// Content-Type, retornando o media type puro usado para buscar o minificador.
+func mediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mediaType
+}
+
+// RenderJSON renderiza name com Content-Type application/json, reaproveitando
+// o mesmo pipeline de cache/ETag/compressão de Render.
+func (r *Renderer) RenderJSON(status_http int, c *gin.Context, name string, data any) {
+	r.RenderWithOptions(status_http, c, name, data, RenderParams{ContentType: "application/json; charset=utf-8"})
+}
+
+// RenderXML renderiza name com Content-Type application/xml, reaproveitando o
+// mesmo pipeline de cache/ETag/compressão de Render. Útil para sitemaps,
+// feeds RSS/Atom e blogrolls OPML.
+func (r *Renderer) RenderXML(status_http int, c *gin.Context, name string, data any) {
+	r.RenderWithOptions(status_http, c, name, data, RenderParams{ContentType: "application/xml; charset=utf-8"})
+}
+
+// RenderSVG renderiza name com Content-Type image/svg+xml, reaproveitando o
+// mesmo pipeline de cache/ETag/compressão de Render.
+func (r *Renderer) RenderSVG(status_http int, c *gin.Context, name string, data any) {
+	r.RenderWithOptions(status_http, c, name, data, RenderParams{ContentType: "image/svg+xml"})
+}
+
+// renderAndCache executa e minifica o template indicado, pré-computa suas
+// variantes comprimidas e armazena o resultado em key. É o corpo executado
+// pelo líder do singleflight, chamado no máximo uma vez por key por mais
+// requisições que sejam coalescidas. Uma execução com erro nunca é cacheada.
+func (r *Renderer) renderAndCache(key, contentType, mediaType, name string, data any, tags []string) (CachedItem, error) {
+	// another goroutine may have populated the cache between our initial miss
+	// check and acquiring the singleflight lock
+	if v, ok := r.cache.Get(key); ok {
+		ci := v.(CachedItem)
+		if time.Since(ci.CreatedAt) < r.ttl {
+			return ci, nil
+		}
+		r.cache.Remove(key)
+	}
+
+	tmpl := r.currentTemplate()
 	if tmpl == nil {
-		log.Printf("no template loaded")
-		c.String(http.StatusInternalServerError, "template error")
-		return
+		return CachedItem{}, errors.New("no template loaded")
 	}
+
+	buf := &bytes.Buffer{}
 	if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
-		log.Printf("template execute error: %v", err)
-		c.String(http.StatusInternalServerError, "template render error")
-		return
+		return CachedItem{}, fmt.Errorf("template execute error: %w", err)
 	}
 
-	// minify
 	dst := &bytes.Buffer{}
-	if err := r.minifier.Minify("text/html", dst, bytes.NewReader(buf.Bytes())); err != nil {
+	if err := r.minifier.Minify(mediaType, dst, bytes.NewReader(buf.Bytes())); err != nil {
 		log.Printf("minify error (fallback): %v", err)
 		dst.Reset()
 		_, _ = io.Copy(dst, bytes.NewReader(buf.Bytes()))
 	}
 
-	// If cache enabled -> compute etag, set headers and store to cache
-	if r.cache != nil {
-		sum := sha256.Sum256(dst.Bytes())
-		etag := hex.EncodeToString(sum[:])
-		
+	sum := sha256.Sum256(dst.Bytes())
+	etag := hex.EncodeToString(sum[:])
 
-		c.Header("Content-Type", contentType)
-		c.Header("ETag", `W/"`+etag+`"`)
-		c.Header("Cache-Control", "public, max-age=60")
-		c.Header("Vary", "Accept-Encoding")
+	ci := CachedItem{
+		Body:        dst.Bytes(),
+		ETag:        etag,
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
 
-		ci := CachedItem{
-			Body:        dst.Bytes(),
-			ETag:        etag,
-			ContentType: contentType,
-			CreatedAt:   time.Now(),
+	if r.gzipEnabled {
+		if gz, err := gzipCompress(ci.Body, r.gzipLevel); err != nil {
+			log.Printf("gzip compress error: %v", err)
+		} else {
+			ci.Gzip = gz
+		}
+	}
+	if r.brotliEnabled {
+		if br, err := brotliCompress(ci.Body, r.brotliLevel); err != nil {
+			log.Printf("brotli compress error: %v", err)
+		} else {
+			ci.Brotli = br
 		}
-		r.cache.Add(key, ci)
+	}
+
+	r.cache.Add(key, ci)
+	if len(tags) > 0 {
+		r.registerTags(key, tags)
+	}
 
-		c.Writer.WriteHeader(status_http)
-		_, _ = io.Copy(c.Writer, bytes.NewReader(dst.Bytes()))
+	return ci, nil
+}
+
+// serve escreve uma CachedItem na resposta, honrando If-None-Match (ETag,
+// verificação primária) e If-Modified-Since (fallback para clientes que não
+// ecoam ETags), e selecionando a melhor codificação disponível (br > gzip >
+// identity) a partir do header Accept-Encoding da requisição.
+func (r *Renderer) serve(c *gin.Context, status_http int, ci CachedItem) {
+	lastModified := ci.CreatedAt.UTC().Format(http.TimeFormat)
+
+	notModified := false
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		notModified = inmMatches(inm, ci.ETag)
+	} else {
+		notModified = imsMatches(c.GetHeader("If-Modified-Since"), ci.CreatedAt)
+	}
+	if notModified {
+		c.Header("ETag", `W/"`+ci.ETag+`"`)
+		c.Header("Last-Modified", lastModified)
+		c.Status(http.StatusNotModified)
 		return
 	}
 
-	c.Header("Content-Type", contentType)
+	body := ci.Body
+	enc := bestEncoding(c.GetHeader("Accept-Encoding"), ci)
+	switch enc {
+	case "br":
+		body = ci.Brotli
+	case "gzip":
+		body = ci.Gzip
+	}
+
+	c.Header("Content-Type", ci.ContentType)
+	c.Header("ETag", `W/"`+ci.ETag+`"`)
+	c.Header("Last-Modified", lastModified)
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Header("Vary", "Accept-Encoding")
+	if enc != "" {
+		c.Header("Content-Encoding", enc)
+	}
 	c.Writer.WriteHeader(status_http)
-	_, _ = io.Copy(c.Writer, bytes.NewReader(dst.Bytes()))
+	_, _ = c.Writer.Write(body)
+}
+
+// imsMatches checa o header If-Modified-Since contra CreatedAt (truncado
+// para segundos, como o próprio header). Usado apenas quando o cliente não
+// enviou If-None-Match.
+func imsMatches(ims string, createdAt time.Time) bool {
+	if ims == "" {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !createdAt.Truncate(time.Second).After(t)
 }
 
-// ClearCache limpa todo o cache (se houver)
+// ClearCache limpa todo o cache (se houver) e a índice de tags associada.
 func (r *Renderer) ClearCache() {
+	r.tagsMu.Lock()
+	r.tagIndex = make(map[string]map[string]struct{})
+	r.keyTags = make(map[string]map[string]struct{})
+	r.tagsMu.Unlock()
+
 	if r.cache == nil {
 		return
 	}
@@ -252,8 +431,11 @@ func (r *Renderer) DisableCache() {
 	r.cache = nil
 }
 
-// InvalidateKey remove uma chave específica (se cache ativo)
+// InvalidateKey remove uma chave específica (se cache ativo) e sua entrada
+// em tagIndex/keyTags, para não deixá-la presa lá depois que a chave já
+// saiu do cache.
 func (r *Renderer) InvalidateKey(key string) {
+	r.untagKey(key)
 	if r.cache == nil {
 		return
 	}
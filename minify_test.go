@@ -0,0 +1,152 @@
+package minify_render
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/tdewolff/minify/v2"
+	minhtml "github.com/tdewolff/minify/v2/html"
+)
+
+func TestInmMatches(t *testing.T) {
+	const etag = "abc123"
+
+	cases := []struct {
+		name string
+		inm  string
+		want bool
+	}{
+		{"empty header", "", false},
+		{"weak match", `W/"abc123"`, true},
+		{"strong match", `"abc123"`, true},
+		{"one of several values", `"other", W/"abc123"`, true},
+		{"no match", `W/"different"`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := inmMatches(tc.inm, etag); got != tc.want {
+				t.Errorf("inmMatches(%q, %q) = %v, want %v", tc.inm, etag, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestImsMatches(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		ims  string
+		want bool
+	}{
+		{"empty header", "", false},
+		{"unparseable header", "not a date", false},
+		{"exact match", createdAt.Format(http.TimeFormat), true},
+		{"client's copy is newer", createdAt.Add(time.Minute).Format(http.TimeFormat), true},
+		{"client's copy is older", createdAt.Add(-time.Minute).Format(http.TimeFormat), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := imsMatches(tc.ims, createdAt); got != tc.want {
+				t.Errorf("imsMatches(%q, %v) = %v, want %v", tc.ims, createdAt, got, tc.want)
+			}
+		})
+	}
+}
+
+// newTestRenderer builds a Renderer directly (bypassing NewRendererWithOptions,
+// which requires template files on disk) around the single named template
+// tmplSrc, with caching enabled via a small LRU.
+func newTestRenderer(t *testing.T, tmplName, tmplSrc string) *Renderer {
+	t.Helper()
+
+	tmpl, err := template.New(tmplName).Parse(tmplSrc)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	m := minify.New()
+	m.Add("text/html", &minhtml.Minifier{KeepDocumentTags: true})
+
+	r := &Renderer{
+		minifier:        m,
+		templateVersion: "test",
+		ttl:             time.Minute,
+		tagIndex:        make(map[string]map[string]struct{}),
+		keyTags:         make(map[string]map[string]struct{}),
+	}
+	c, err := lru.NewWithEvict(16, r.onEvicted)
+	if err != nil {
+		t.Fatalf("new lru: %v", err)
+	}
+	r.cache = c
+	r.templatesVal.Store(tmpl)
+
+	return r
+}
+
+// TestRenderAndCache_SingleflightCoalescesExecutions drives renderAndCache's
+// singleflight group concurrently and asserts the template body executes
+// exactly once per key, regardless of how many goroutines race on the
+// initial cache miss.
+func TestRenderAndCache_SingleflightCoalescesExecutions(t *testing.T) {
+	var executions int64
+	r := newTestRenderer(t, "page", "")
+
+	funcs := template.FuncMap{
+		"sleep": func() string {
+			atomic.AddInt64(&executions, 1)
+			time.Sleep(10 * time.Millisecond)
+			return ""
+		},
+	}
+	tmpl, err := template.New("page").Funcs(funcs).Parse(`{{sleep}}{{.}}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	r.templatesVal.Store(tmpl)
+
+	const n = 20
+	const key = "shared-key"
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			v, err, _ := r.sf.Do(key, func() (interface{}, error) {
+				return r.renderAndCache(key, defaultContentType, "text/html", "page", "hello", nil)
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if _, ok := v.(CachedItem); !ok {
+				errs[i] = fmt.Errorf("unexpected result type %T", v)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("goroutine %d: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt64(&executions); got != 1 {
+		t.Errorf("template executed %d times, want exactly 1 (singleflight should coalesce concurrent misses)", got)
+	}
+}
@@ -0,0 +1,82 @@
+package minify_render
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HaveTemplate reporta se o template atualmente carregado define name,
+// permitindo ao chamador decidir entre renderizar um template ou um
+// fallback padrão.
+func (r *Renderer) HaveTemplate(name string) bool {
+	tmpl := r.currentTemplate()
+	if tmpl == nil {
+		return false
+	}
+	return tmpl.Lookup(name) != nil
+}
+
+// RenderError renderiza o template de erro correspondente a status (ex.:
+// "404.html", "500.html") através do mesmo pipeline de minify usado por
+// Render, mas sempre direto para o writer: cada erro é por natureza
+// potencialmente distinto, e cachear sua saída sob a chave genérica da rota
+// serviria o corpo de um erro antigo para um erro novo e não relacionado.
+// Se o template não existir, ou se ele mesmo entrar em panic ao executar,
+// cai para uma resposta em texto plano.
+func (r *Renderer) RenderError(c *gin.Context, status int, err error) {
+	name := fmt.Sprintf("%d.html", status)
+	if !r.HaveTemplate(name) {
+		r.writePlainError(c, status)
+		return
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("panic rendering error template %q: %v\n%s", name, rec, debug.Stack())
+			r.writePlainError(c, status)
+		}
+	}()
+
+	contentType := defaultContentType
+	if renderErr := r.renderDirect(status, c, name, gin.H{"Status": status, "Error": err}, contentType, mediaTypeOf(contentType)); renderErr != nil {
+		log.Printf("render error template %q failed: %v", name, renderErr)
+		r.writePlainError(c, status)
+	}
+}
+
+// writePlainError escreve uma resposta de erro mínima, sem template. Usado
+// sempre que renderizar o template de erro fornecido pelo usuário não é
+// possível ou não é seguro (template ausente, falha ao renderizar, ou um
+// panic recuperado).
+func (r *Renderer) writePlainError(c *gin.Context, status int) {
+	msg := http.StatusText(status)
+	if msg == "" {
+		msg = "error"
+	}
+	c.String(status, msg)
+}
+
+// RecoveryMiddleware recupera de panics em handlers subsequentes, loga o
+// stack trace e responde via RenderError com status 500.
+func (r *Renderer) RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic recovered: %v\n%s", rec, debug.Stack())
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+
+				c.Abort()
+				r.RenderError(c, http.StatusInternalServerError, err)
+			}
+		}()
+		c.Next()
+	}
+}
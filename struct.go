@@ -1,17 +1,23 @@
 package minify_render
 
 import (
+	"context"
 	"html/template"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/tdewolff/minify/v2"
+	"golang.org/x/sync/singleflight"
 )
 
 // CachedItem guarda o corpo minificado e o etag
 type CachedItem struct {
 	Body        []byte
+	Gzip        []byte
+	Brotli      []byte
 	ETag        string
 	ContentType string
 	CreatedAt   time.Time
@@ -28,11 +34,56 @@ type Renderer struct {
 	minifier        *minify.M
 	cache           *lru.Cache
 	templateVersion string
+	templateGen     int64 // bumped on every ReloadTemplates, appended to cache keys
 	ttl             time.Duration
+	sf              singleflight.Group
+
+	gzipEnabled   bool
+	gzipLevel     int
+	brotliEnabled bool
+	brotliLevel   int
+
+	tagsMu   sync.Mutex
+	tagIndex map[string]map[string]struct{} // tag -> set of cache keys
+	keyTags  map[string]map[string]struct{} // cache key -> set of tags
+
+	watchMu     sync.Mutex
+	watching    bool // set under watchMu for the duration of WatchTemplates' setup, to reject a concurrent second call before r.watcher exists
+	watcher     *fsnotify.Watcher
+	watchCancel context.CancelFunc
+}
+
+// RendererOptions controla quais variantes de compressão o Renderer deve
+// calcular e armazenar em cache a cada inserção, usado por
+// NewRendererWithOptions.
+type RendererOptions struct {
+	GzipEnabled bool
+	// GzipLevel é o nível passado a gzip.NewWriterLevel (ex.:
+	// gzip.NoCompression..gzip.BestCompression). nil usa
+	// gzip.DefaultCompression — diferente de um ponteiro para 0, que pede
+	// gzip.NoCompression explicitamente.
+	GzipLevel     *int
+	BrotliEnabled bool
+	// BrotliLevel é o nível passado a brotli.NewWriterLevel. nil usa
+	// brotli.DefaultCompression.
+	BrotliLevel *int
 }
 
+// RenderParams carrega opções por-render que não fazem parte da assinatura
+// fixa de Render, usado por RenderWithOptions. StatusHttp, Template e Data não
+// são lidos por RenderWithOptions, que usa os argumentos da própria chamada.
 type RenderParams struct {
 	StatusHttp int
 	Template   string
 	Data       any
+
+	// ContentType sobrescreve o Content-Type da resposta (e o minificador
+	// usado) — ex.: "application/json; charset=utf-8". Vazio usa
+	// "text/html; charset=utf-8".
+	ContentType string
+
+	// CacheTags associa o item de cache gerado a um ou mais identificadores
+	// (ex.: "post:42", "user:7"), permitindo invalidação seletiva via
+	// InvalidateTag/InvalidateTags em vez de um ClearCache geral.
+	CacheTags []string
 }
@@ -0,0 +1,83 @@
+package minify_render
+
+// registerTags associa key a cada tag em tags na índice reversa, usado por
+// InvalidateTag/InvalidateTags/TagsForKey.
+func (r *Renderer) registerTags(key string, tags []string) {
+	r.tagsMu.Lock()
+	defer r.tagsMu.Unlock()
+
+	if r.keyTags[key] == nil {
+		r.keyTags[key] = make(map[string]struct{})
+	}
+	for _, tag := range tags {
+		if r.tagIndex[tag] == nil {
+			r.tagIndex[tag] = make(map[string]struct{})
+		}
+		r.tagIndex[tag][key] = struct{}{}
+		r.keyTags[key][tag] = struct{}{}
+	}
+}
+
+// InvalidateTag evicta do cache toda CachedItem associada a tag e limpa a
+// índice reversa correspondente.
+func (r *Renderer) InvalidateTag(tag string) {
+	r.tagsMu.Lock()
+	keys := r.tagIndex[tag]
+	delete(r.tagIndex, tag)
+
+	affected := make([]string, 0, len(keys))
+	for key := range keys {
+		affected = append(affected, key)
+		r.untagKeyLocked(key)
+	}
+	r.tagsMu.Unlock()
+
+	if r.cache == nil {
+		return
+	}
+	for _, key := range affected {
+		r.cache.Remove(key)
+	}
+}
+
+// untagKey remove key de keyTags e de cada tag em tagIndex que o referencia.
+// Usado pelo callback de evicção do LRU (onEvicted) e por InvalidateKey, para
+// que as índices de tag nunca fiquem apontando para uma entrada que já saiu
+// do cache.
+func (r *Renderer) untagKey(key string) {
+	r.tagsMu.Lock()
+	defer r.tagsMu.Unlock()
+	r.untagKeyLocked(key)
+}
+
+// untagKeyLocked é untagKey assumindo que tagsMu já está travado pelo
+// chamador.
+func (r *Renderer) untagKeyLocked(key string) {
+	for t := range r.keyTags[key] {
+		delete(r.tagIndex[t], key)
+		if len(r.tagIndex[t]) == 0 {
+			delete(r.tagIndex, t)
+		}
+	}
+	delete(r.keyTags, key)
+}
+
+// InvalidateTags invalida cada tag informada.
+func (r *Renderer) InvalidateTags(tags ...string) {
+	for _, tag := range tags {
+		r.InvalidateTag(tag)
+	}
+}
+
+// TagsForKey retorna as tags associadas a uma chave de cache, para debug.
+func (r *Renderer) TagsForKey(key string) []string {
+	r.tagsMu.Lock()
+	defer r.tagsMu.Unlock()
+
+	tags := r.keyTags[key]
+	out := make([]string, 0, len(tags))
+	for tag := range tags {
+		out = append(out, tag)
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package minify_render
+
+import (
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+func newTaggedTestRenderer(t *testing.T) *Renderer {
+	t.Helper()
+
+	r := &Renderer{
+		ttl:      time.Minute,
+		tagIndex: make(map[string]map[string]struct{}),
+		keyTags:  make(map[string]map[string]struct{}),
+	}
+	c, err := lru.NewWithEvict(2, r.onEvicted)
+	if err != nil {
+		t.Fatalf("new lru: %v", err)
+	}
+	r.cache = c
+	return r
+}
+
+func TestInvalidateTag(t *testing.T) {
+	r := newTaggedTestRenderer(t)
+
+	r.cache.Add("post:1", CachedItem{Body: []byte("a")})
+	r.cache.Add("post:2", CachedItem{Body: []byte("b")})
+	r.registerTags("post:1", []string{"post:1", "author:7"})
+	r.registerTags("post:2", []string{"post:2", "author:7"})
+
+	r.InvalidateTag("author:7")
+
+	if _, ok := r.cache.Get("post:1"); ok {
+		t.Error("post:1 should have been evicted by InvalidateTag(\"author:7\")")
+	}
+	if _, ok := r.cache.Get("post:2"); ok {
+		t.Error("post:2 should have been evicted by InvalidateTag(\"author:7\")")
+	}
+	if tags := r.TagsForKey("post:1"); len(tags) != 0 {
+		t.Errorf("TagsForKey(post:1) = %v, want empty after invalidation", tags)
+	}
+	if _, ok := r.tagIndex["author:7"]; ok {
+		t.Error("tagIndex[author:7] should have been removed")
+	}
+}
+
+// TestLRUEvictionPrunesTagIndex is the regression test for the leak where
+// capacity-driven LRU evictions left tagIndex/keyTags entries behind forever
+// because only ClearCache/InvalidateTag ever pruned them.
+func TestLRUEvictionPrunesTagIndex(t *testing.T) {
+	r := newTaggedTestRenderer(t) // capacity 2
+
+	r.cache.Add("k1", CachedItem{})
+	r.registerTags("k1", []string{"tag-a"})
+	r.cache.Add("k2", CachedItem{})
+	r.registerTags("k2", []string{"tag-b"})
+
+	// capacity is 2; adding a third key evicts k1 (least recently used)
+	r.cache.Add("k3", CachedItem{})
+	r.registerTags("k3", []string{"tag-c"})
+
+	if _, ok := r.cache.Get("k1"); ok {
+		t.Fatal("expected k1 to have been evicted by capacity pressure")
+	}
+	if tags := r.TagsForKey("k1"); len(tags) != 0 {
+		t.Errorf("TagsForKey(k1) = %v, want empty: onEvicted should have pruned it", tags)
+	}
+	if _, ok := r.tagIndex["tag-a"]; ok {
+		t.Error("tagIndex[tag-a] should have been removed once its only key was evicted")
+	}
+}
+
+func TestInvalidateKeyPrunesTagIndex(t *testing.T) {
+	r := newTaggedTestRenderer(t)
+
+	r.cache.Add("k1", CachedItem{})
+	r.registerTags("k1", []string{"tag-a"})
+
+	r.InvalidateKey("k1")
+
+	if _, ok := r.cache.Get("k1"); ok {
+		t.Error("k1 should have been removed from the cache")
+	}
+	if tags := r.TagsForKey("k1"); len(tags) != 0 {
+		t.Errorf("TagsForKey(k1) = %v, want empty after InvalidateKey", tags)
+	}
+	if _, ok := r.tagIndex["tag-a"]; ok {
+		t.Error("tagIndex[tag-a] should have been removed")
+	}
+}
@@ -0,0 +1,155 @@
+package minify_render
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const templateWatchDebounce = 200 * time.Millisecond
+
+// WatchTemplates observa todos os diretórios cobertos por templatePattern e
+// chama ReloadTemplates sempre que um arquivo correspondente é escrito,
+// criado, renomeado ou removido, debatendo rajadas de eventos. Diferente do
+// reload por-requisição feito em gin.DebugMode, isso permite a operadores de
+// staging/produção receber atualizações de template ao vivo sem pagar esse
+// custo a cada requisição. Chame Stop (ou cancele ctx) para desligar o
+// watcher. Retorna erro se já houver um watcher ativo — chame Stop antes de
+// chamar WatchTemplates de novo, senão o watcher anterior e sua goroutine
+// vazam, sem nada que ainda consiga pará-los.
+func (r *Renderer) WatchTemplates(ctx context.Context) error {
+	r.watchMu.Lock()
+	if r.watching || r.watcher != nil {
+		r.watchMu.Unlock()
+		return errors.New("minify_render: WatchTemplates already active, call Stop first")
+	}
+	// claim the slot before doing any of the (slower) setup below, so a
+	// second concurrent call can't also pass the check above before r.watcher
+	// is set and orphan a watcher goroutine that nothing can ever stop
+	r.watching = true
+	r.watchMu.Unlock()
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.clearWatching()
+		return err
+	}
+
+	dirs, err := r.templateDirs()
+	if err != nil {
+		_ = w.Close()
+		r.clearWatching()
+		return err
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			_ = w.Close()
+			r.clearWatching()
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.watchMu.Lock()
+	r.watcher = w
+	r.watchCancel = cancel
+	r.watchMu.Unlock()
+
+	go r.watchLoop(ctx, w)
+
+	return nil
+}
+
+// Stop desliga o watcher iniciado por WatchTemplates, se houver.
+func (r *Renderer) Stop() {
+	r.watchMu.Lock()
+	cancel := r.watchCancel
+	r.watchCancel = nil
+	r.watcher = nil
+	r.watching = false
+	r.watchMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// clearWatching libera o slot reservado no início de WatchTemplates quando o
+// setup falha antes de um watcher ser de fato instalado.
+func (r *Renderer) clearWatching() {
+	r.watchMu.Lock()
+	r.watching = false
+	r.watchMu.Unlock()
+}
+
+// templateDirs expande templatePattern e retorna o conjunto de diretórios
+// pai que o fsnotify precisa observar (ele só observa diretórios, não
+// padrões glob).
+func (r *Renderer) templateDirs() (map[string]struct{}, error) {
+	matches, err := filepath.Glob(r.templatePattern)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]struct{}, len(matches))
+	for _, m := range matches {
+		dirs[filepath.Dir(m)] = struct{}{}
+	}
+	return dirs, nil
+}
+
+func (r *Renderer) watchLoop(ctx context.Context, w *fsnotify.Watcher) {
+	defer func() { _ = w.Close() }()
+
+	var timer *time.Timer
+	timerC := func() <-chan time.Time {
+		if timer == nil {
+			return nil
+		}
+		return timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(templateWatchDebounce)
+			} else {
+				timer.Reset(templateWatchDebounce)
+			}
+
+		case <-timerC():
+			timer = nil
+			if err := r.ReloadTemplates(); err != nil {
+				log.Printf("template watch: reload failed: %v", err)
+				continue
+			}
+			// the reload may have picked up files in directories we aren't
+			// watching yet (e.g. a renamed subdirectory) - catch up
+			if dirs, err := r.templateDirs(); err == nil {
+				for dir := range dirs {
+					_ = w.Add(dir) // no-op if already watched
+				}
+			}
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("template watch error: %v", err)
+		}
+	}
+}